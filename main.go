@@ -1,14 +1,19 @@
 package main
 
 import (
+	"bytes"
+	_ "embed"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 type OpenAPI struct {
@@ -17,23 +22,76 @@ type OpenAPI struct {
 		Description string `json:"description"`
 		Version     string `json:"version"`
 	} `json:"info"`
-	Paths      map[string]map[string]Method `json:"paths"`
+	Servers    []Server            `json:"servers"`
+	Tags       []Tag               `json:"tags"`
+	Paths      map[string]PathItem `json:"paths"`
 	Components struct {
-		Schemas map[string]Schema `json:"schemas"`
+		Schemas   map[string]Schema   `json:"schemas"`
+		Examples  map[string]Example  `json:"examples"`
+		PathItems map[string]PathItem `json:"pathItems"`
 	} `json:"components"`
 }
 
+type PathItem map[string]Method
+
+func (p *PathItem) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	item := make(PathItem)
+	for key, value := range fields {
+		switch key {
+		case "get", "put", "post", "delete", "options", "head", "patch", "trace", "connect":
+		default:
+			continue
+		}
+
+		var operation Method
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.UseNumber()
+		err = decoder.Decode(&operation)
+		if err != nil {
+			return err
+		}
+		item[key] = operation
+	}
+	*p = item
+
+	return nil
+}
+
+type Tag struct {
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	ExternalDocs *ExternalDocs `json:"externalDocs"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
 type Method struct {
 	OperationID string  `json:"operationId"`
 	Summary     string  `json:"summary"`
 	Description *string `json:"description"`
 	Parameters  []struct {
-		Name     string `json:"name"`
-		Required bool   `json:"required"`
-		In       string `json:"in"`
-		Schema   struct {
-			Type string `json:"type"`
+		Name       string `json:"name"`
+		Required   bool   `json:"required"`
+		In         string `json:"in"`
+		Deprecated bool   `json:"deprecated"`
+		Schema     struct {
+			Type string        `json:"type"`
+			Enum []interface{} `json:"enum"`
 		} `json:"schema"`
+		Content map[string]struct {
+			Schema struct {
+				Type string `json:"type"`
+			} `json:"schema"`
+		} `json:"content"`
 	} `json:"parameters"`
 	RequestBody struct {
 		Required bool `json:"required"`
@@ -46,6 +104,8 @@ type Method struct {
 						Ref string `json:"$ref"`
 					} `json:"items"`
 				} `json:"schema"`
+				Example  json.RawMessage    `json:"example"`
+				Examples map[string]Example `json:"examples"`
 			} `json:"application/json"`
 		} `json:"content"`
 	} `json:"requestBody"`
@@ -60,24 +120,93 @@ type Method struct {
 						Ref string `json:"$ref"`
 					} `json:"items"`
 				} `json:"schema"`
+				Example  json.RawMessage    `json:"example"`
+				Examples map[string]Example `json:"examples"`
 			} `json:"application/json"`
 		} `json:"content"`
 	} `json:"responses"`
-	Tags []string `json:"tags"`
+	Tags         []string      `json:"tags"`
+	ExternalDocs *ExternalDocs `json:"externalDocs"`
+}
+
+type ExternalDocs struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type Example struct {
+	Ref     string          `json:"$ref"`
+	Summary string          `json:"summary"`
+	Value   json.RawMessage `json:"value"`
 }
 
 type Schema struct {
-	Type       string `json:"type"`
-	Properties map[string]struct {
-		Format   string  `json:"format"`
-		Type     string  `json:"type"`
-		Example  *string `json:"example"`
-		Nullable bool    `json:"nullable"`
-	} `json:"properties"`
-	Required []string `json:"required"`
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties"`
+	Required   []string            `json:"required"`
+}
+
+type Property struct {
+	Format    string        `json:"format"`
+	Type      string        `json:"type"`
+	Example   interface{}   `json:"example"`
+	Default   interface{}   `json:"default"`
+	Const     interface{}   `json:"const"`
+	Enum      []interface{} `json:"enum"`
+	Nullable  bool          `json:"nullable"`
+	ReadOnly  bool          `json:"readOnly"`
+	WriteOnly bool          `json:"writeOnly"`
+}
+
+type Stats struct {
+	Paths      int
+	Operations map[string]int
+	Parameters int
+	Responses  map[string]int
+	Schemas    int
+}
+
+type LintFinding struct {
+	Severity string
+	Location string
+	Message  string
+}
+
+type ConversionOptions struct {
+	EmitGroups           bool
+	OperationIDNames     bool
+	KeepEmptyResponses   bool
+	PreserveExampleOrder bool
+	MaxHeadingLength     int
+	OmitEmpty            bool
+}
+
+//go:embed selftest/openapi.json
+var selfTestOpenAPI []byte
+
+//go:embed selftest/openapi.apib
+var selfTestBlueprint string
+
+var transforms = make(map[string]func(*OpenAPI) error)
+
+func init() {
+	registerTransform("strip-descriptions", stripDescriptions)
+}
+
+func registerTransform(name string, fn func(*OpenAPI) error) {
+	transforms[name] = fn
 }
 
 func main() {
+	if len(os.Args) == 2 && os.Args[1] == "selftest" {
+		err := runSelfTest(os.Stdout)
+		if err != nil {
+			fmt.Printf("FAIL %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(os.Args) < 3 {
 		fmt.Println("Usage: apibconv -f input.json -o output.apib")
 		return
@@ -85,6 +214,21 @@ func main() {
 
 	inputFlag := flag.String("f", "", "Path to the input OpenAPI JSON file")
 	outputFlag := flag.String("o", "", "Path to the output API Blueprint file")
+	noGroupFlag := flag.Bool("no-group", false, "Do not emit group headings for operation tags")
+	operationIDNamesFlag := flag.Bool("operation-id-names", false, "Use the humanized operationId as the action name when summary is empty")
+	countFlag := flag.Bool("count", false, "Print only the number of paths and operations instead of converting")
+	statsFlag := flag.Bool("stats", false, "Print a summary of the converted spec to stderr")
+	baseURLFlag := flag.String("base-url", "", "Comma-separated server URLs that replace the servers of the input")
+	selectFlag := flag.String("select", "", "Comma-separated path patterns to convert, where * matches any characters")
+	normalizePathsFlag := flag.Bool("normalize-paths", false, "Collapse trailing slashes in paths and warn about paths differing only by case")
+	resolveRefsFlag := flag.Bool("resolve-refs", false, "Inline schemas referenced from external files relative to the input file")
+	noEmptyResponsesFlag := flag.Bool("no-empty-responses", false, "Omit responses that have neither content nor a description")
+	preserveExampleOrderFlag := flag.Bool("preserve-example-order", false, "Keep the original key order of example bodies instead of sorting keys")
+	transformFlag := flag.String("transform", "", "Comma-separated names of transforms to apply before conversion")
+	maxHeadingLengthFlag := flag.Int("max-heading-length", 0, "Truncate action names longer than this many characters, moving the full text into the description (0 means unlimited)")
+	omitEmptyFlag := flag.Bool("omit-empty", false, "Omit empty attribute and body sections")
+	lintFlag := flag.Bool("lint", false, "Report style recommendations for the input instead of converting it")
+	dryRunFlag := flag.Bool("dry-run", false, "Convert without writing the output file and report its size")
 
 	flag.Parse()
 
@@ -93,7 +237,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if *outputFlag == "" {
+	if *outputFlag == "" && !*dryRunFlag && !*lintFlag && !*countFlag {
 		fmt.Println("Error: Output file is required")
 		os.Exit(1)
 	}
@@ -110,86 +254,700 @@ func main() {
 		os.Exit(1)
 	}
 
-	apiBlueprint, err := createAPIBlueprint(api)
+	if *countFlag {
+		printCounts(os.Stdout, specStats(api))
+		return
+	}
+
+	if *resolveRefsFlag {
+		err = resolveExternalRefs(&api, filepath.Dir(*inputFlag))
+		if err != nil {
+			fmt.Printf("Error: Cannot resolve references in '%s': %v\n", *inputFlag, err)
+			os.Exit(1)
+		}
+	}
+
+	if *normalizePathsFlag {
+		var warnings []string
+		api, warnings = normalizePaths(api)
+		for _, warning := range warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	if *baseURLFlag != "" {
+		setServers(&api, strings.Split(*baseURLFlag, ","))
+	}
+
+	if *selectFlag != "" {
+		api = filterPaths(api, strings.Split(*selectFlag, ","))
+	}
+
+	if *transformFlag != "" {
+		err = applyTransforms(&api, strings.Split(*transformFlag, ","))
+		if err != nil {
+			fmt.Printf("Error: Cannot transform input file '%s': %v\n", *inputFlag, err)
+			os.Exit(1)
+		}
+	}
+
+	err = validateOpenAPI(api)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	if *lintFlag {
+		for _, finding := range lintOpenAPI(api) {
+			fmt.Printf("%s %s: %s\n", strings.ToUpper(finding.Severity), finding.Location, finding.Message)
+		}
+		return
+	}
+
+	opts := ConversionOptions{
+		EmitGroups:           !*noGroupFlag,
+		OperationIDNames:     *operationIDNamesFlag,
+		KeepEmptyResponses:   !*noEmptyResponsesFlag,
+		PreserveExampleOrder: *preserveExampleOrderFlag,
+		MaxHeadingLength:     *maxHeadingLengthFlag,
+		OmitEmpty:            *omitEmptyFlag,
+	}
+
+	apiBlueprint, err := createAPIBlueprint(api, opts)
 	if err != nil {
 		fmt.Printf("Error: Cannot convert to API Blueprint: %v\n", err)
 		os.Exit(1)
 	}
 
-	err = ioutil.WriteFile(*outputFlag, []byte(apiBlueprint), 0o644)
+	err = writeOutput(os.Stdout, *outputFlag, apiBlueprint, *dryRunFlag)
 	if err != nil {
 		fmt.Printf("Error: Cannot write output file '%s': %v\n", *outputFlag, err)
 		os.Exit(1)
 	}
+
+	if *statsFlag {
+		printStats(os.Stderr, specStats(api))
+	}
+}
+
+func writeOutput(w io.Writer, path, apiBlueprint string, dryRun bool) error {
+	if dryRun {
+		fmt.Fprintf(w, "Dry run: %d bytes of API Blueprint would be written\n", len(apiBlueprint))
+		return nil
+	}
+
+	return ioutil.WriteFile(path, []byte(apiBlueprint), 0o644)
+}
+
+func copyMethod(operation Method) Method {
+	operation.Responses = copyMap(operation.Responses)
+	return operation
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(map[K]V, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
+func runSelfTest(w io.Writer) error {
+	api, err := parseOpenAPI(selfTestOpenAPI)
+	if err != nil {
+		return fmt.Errorf("OpenAPI to API Blueprint: %v", err)
+	}
+
+	opts := ConversionOptions{
+		EmitGroups:         true,
+		OperationIDNames:   true,
+		KeepEmptyResponses: true,
+	}
+
+	apiBlueprint, err := createAPIBlueprint(api, opts)
+	if err != nil {
+		return fmt.Errorf("OpenAPI to API Blueprint: %v", err)
+	}
+
+	if apiBlueprint != selfTestBlueprint {
+		return errors.New("OpenAPI to API Blueprint: output does not match the embedded sample")
+	}
+	fmt.Fprintln(w, "PASS OpenAPI to API Blueprint")
+
+	return nil
 }
 
 func parseOpenAPI(data []byte) (OpenAPI, error) {
 	var api OpenAPI
-	err := json.Unmarshal(data, &api)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&api)
+	if err != nil {
+		return OpenAPI{}, errors.New("unable to parse OpenAPI JSON")
+	}
+
+	var pathRefs struct {
+		Paths map[string]struct {
+			Ref string `json:"$ref"`
+		} `json:"paths"`
+	}
+	err = json.Unmarshal(data, &pathRefs)
 	if err != nil {
 		return OpenAPI{}, errors.New("unable to parse OpenAPI JSON")
 	}
 
+	for path, pathRef := range pathRefs.Paths {
+		if pathRef.Ref == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(pathRef.Ref, "#/components/pathItems/")
+		sharedItem, ok := api.Components.PathItems[name]
+		if !ok {
+			return OpenAPI{}, fmt.Errorf("unable to resolve path item '%s' for '%s'", pathRef.Ref, path)
+		}
+
+		pathItem := make(PathItem)
+		for method, operation := range sharedItem {
+			pathItem[method] = copyMethod(operation)
+		}
+		for method, operation := range api.Paths[path] {
+			if _, ok := pathItem[method]; ok {
+				return OpenAPI{}, fmt.Errorf("operation %s '%s' conflicts with path item '%s'", strings.ToUpper(method), path, pathRef.Ref)
+			}
+			pathItem[method] = operation
+		}
+		api.Paths[path] = pathItem
+	}
+
 	return api, nil
 }
 
-func formatAttributes(schema Schema) string {
-	var sb strings.Builder
+func resolveExternalRefs(api *OpenAPI, baseDir string) error {
+	resolved := make(map[string]string)
 
-	sb.WriteString("+ Attributes \n")
-	for propName, prop := range schema.Properties {
-		var example string
-		if prop.Example != nil {
-			example = ": " + *prop.Example
+	resolve := func(ref string) (string, error) {
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			return ref, nil
 		}
-		if prop.Nullable {
-			sb.WriteString("    + " + propName + example + " (" + prop.Type + ", optional, nullable)\n")
-		} else {
-			sb.WriteString("    + " + propName + example + " (" + prop.Type + ", optional)\n")
+
+		if strings.Contains(ref, "#") || filepath.IsAbs(ref) {
+			return "", fmt.Errorf("unsupported reference '%s'", ref)
+		}
+
+		target := filepath.Join(baseDir, filepath.FromSlash(ref))
+		rel, err := filepath.Rel(baseDir, target)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("reference '%s' points outside of '%s'", ref, baseDir)
 		}
+
+		if name, ok := resolved[target]; ok {
+			return "#/components/schemas/" + name, nil
+		}
+
+		data, err := ioutil.ReadFile(target)
+		if err != nil {
+			return "", fmt.Errorf("cannot read reference '%s': %v", ref, err)
+		}
+
+		var schema Schema
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&schema)
+		if err != nil {
+			return "", fmt.Errorf("cannot parse reference '%s': %v", ref, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+		if _, ok := api.Components.Schemas[name]; ok {
+			return "", fmt.Errorf("reference '%s' conflicts with schema '%s'", ref, name)
+		}
+
+		if api.Components.Schemas == nil {
+			api.Components.Schemas = make(map[string]Schema)
+		}
+		api.Components.Schemas[name] = schema
+		resolved[target] = name
+
+		return "#/components/schemas/" + name, nil
 	}
 
-	return sb.String()
+	var err error
+	for _, methods := range api.Paths {
+		for method, operation := range methods {
+			requestBodySchema := &operation.RequestBody.Content.ApplicationJSON.Schema
+			if requestBodySchema.Ref, err = resolve(requestBodySchema.Ref); err != nil {
+				return err
+			}
+			if requestBodySchema.Items.Ref, err = resolve(requestBodySchema.Items.Ref); err != nil {
+				return err
+			}
+
+			for code, response := range operation.Responses {
+				responseBodySchema := &response.Content.ApplicationJSON.Schema
+				if responseBodySchema.Ref, err = resolve(responseBodySchema.Ref); err != nil {
+					return err
+				}
+				if responseBodySchema.Items.Ref, err = resolve(responseBodySchema.Items.Ref); err != nil {
+					return err
+				}
+				operation.Responses[code] = response
+			}
+
+			methods[method] = operation
+		}
+	}
+
+	return nil
 }
 
-func formatBody(schemaType string, schema Schema) string {
-	if schemaType == "object" {
-		example := make(map[string]interface{})
+func applyTransforms(api *OpenAPI, names []string) error {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		transform, ok := transforms[name]
+		if !ok {
+			return fmt.Errorf("unknown transform '%s'", name)
+		}
+
+		err := transform(api)
+		if err != nil {
+			return fmt.Errorf("transform '%s' failed: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func stripDescriptions(api *OpenAPI) error {
+	api.Info.Description = ""
 
-		for propName, prop := range schema.Properties {
-			propValue := propValue(prop.Type)
-			if prop.Example != nil {
-				propValue = *prop.Example
+	for i := range api.Tags {
+		api.Tags[i].Description = ""
+	}
+
+	for _, methods := range api.Paths {
+		for method, operation := range methods {
+			operation.Description = nil
+
+			for code, response := range operation.Responses {
+				response.Description = ""
+				operation.Responses[code] = response
 			}
 
-			if prop.Nullable {
-				example[propName] = nil
+			methods[method] = operation
+		}
+	}
+
+	return nil
+}
+
+func setServers(api *OpenAPI, urls []string) {
+	servers := make([]Server, 0, len(urls))
+	for _, url := range urls {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		servers = append(servers, Server{URL: url})
+	}
+	api.Servers = servers
+}
+
+func normalizePaths(api OpenAPI) (OpenAPI, []string) {
+	var warnings []string
+
+	sortedPaths := make([]string, 0, len(api.Paths))
+	for path := range api.Paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	paths := make(map[string]PathItem)
+	folded := make(map[string]string)
+	for _, path := range sortedPaths {
+		normalized := strings.TrimRight(path, "/")
+		if normalized == "" {
+			normalized = "/"
+		}
+
+		if paths[normalized] == nil {
+			if other, ok := folded[strings.ToLower(normalized)]; ok {
+				warnings = append(warnings, fmt.Sprintf("paths '%s' and '%s' differ only by case", other, normalized))
 			} else {
-				example[propName] = propValue
+				folded[strings.ToLower(normalized)] = normalized
 			}
+
+			paths[normalized] = make(PathItem)
 		}
+		for method, operation := range api.Paths[path] {
+			if _, ok := paths[normalized][method]; ok {
+				warnings = append(warnings, fmt.Sprintf("ignoring duplicate operation %s %s", strings.ToUpper(method), path))
+				continue
+			}
+			paths[normalized][method] = operation
+		}
+	}
+	api.Paths = paths
 
-		jsonBytes, _ := json.MarshalIndent(example, "        ", "    ")
-		return string(jsonBytes)
-	} else if schemaType == "array" {
-		example := make([]interface{}, 1)
+	return api, warnings
+}
+
+func matchPath(pattern, path string) bool {
+	parts := strings.Split(strings.TrimSpace(pattern), "*")
+	if len(parts) == 1 {
+		return parts[0] == path
+	}
 
-		for propName, prop := range schema.Properties {
-			propValue := propValue(prop.Type)
-			if prop.Example != nil {
-				propValue = *prop.Example
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(path, part)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(part):]
+	}
+
+	return strings.HasSuffix(path, parts[len(parts)-1])
+}
+
+func filterPaths(api OpenAPI, patterns []string) OpenAPI {
+	paths := make(map[string]PathItem)
+	for path, methods := range api.Paths {
+		for _, pattern := range patterns {
+			if matchPath(pattern, path) {
+				paths[path] = methods
+				break
 			}
-			if prop.Nullable {
-				example[0] = map[string]interface{}{propName: nil}
-			} else {
-				example[0] = map[string]interface{}{propName: propValue}
+		}
+	}
+	api.Paths = paths
+
+	referenced := make(map[string]bool)
+	for _, methods := range api.Paths {
+		for _, operation := range methods {
+			requestBodySchema := operation.RequestBody.Content.ApplicationJSON.Schema
+			referenced[requestBodySchema.Ref] = true
+			referenced[requestBodySchema.Items.Ref] = true
+
+			for _, response := range operation.Responses {
+				responseBodySchema := response.Content.ApplicationJSON.Schema
+				referenced[responseBodySchema.Ref] = true
+				referenced[responseBodySchema.Items.Ref] = true
+			}
+		}
+	}
+
+	schemas := make(map[string]Schema)
+	for name, schema := range api.Components.Schemas {
+		if referenced["#/components/schemas/"+name] {
+			schemas[name] = schema
+		}
+	}
+	api.Components.Schemas = schemas
+
+	return api
+}
+
+func validateOpenAPI(api OpenAPI) error {
+	sortedPaths := make([]string, 0, len(api.Paths))
+	for path := range api.Paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	operationIDs := make(map[string]string)
+	for _, path := range sortedPaths {
+		methods := api.Paths[path]
+
+		sortedMethods := make([]string, 0, len(methods))
+		for method := range methods {
+			sortedMethods = append(sortedMethods, method)
+		}
+		sort.Strings(sortedMethods)
+
+		for _, method := range sortedMethods {
+			operationID := methods[method].OperationID
+			if operationID == "" {
+				continue
 			}
+
+			location := strings.ToUpper(method) + " " + path
+			if previous, ok := operationIDs[operationID]; ok {
+				return fmt.Errorf("duplicate operationId '%s' in %s and %s", operationID, previous, location)
+			}
+			operationIDs[operationID] = location
 		}
+	}
 
-		jsonBytes, _ := json.MarshalIndent(example, "        ", "    ")
+	return nil
+}
+
+func lintOpenAPI(api OpenAPI) []LintFinding {
+	var findings []LintFinding
+
+	sortedPaths := make([]string, 0, len(api.Paths))
+	for path := range api.Paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	segmentPaths := make(map[string]string)
+	for _, path := range sortedPaths {
+		for _, segment := range strings.Split(path, "/") {
+			if segment == "" || strings.HasPrefix(segment, "{") {
+				continue
+			}
+			if _, ok := segmentPaths[strings.ToLower(segment)]; !ok {
+				segmentPaths[strings.ToLower(segment)] = path
+			}
+			if segment != strings.ToLower(segment) || strings.Contains(segment, "_") {
+				findings = append(findings, LintFinding{
+					Severity: "info",
+					Location: path,
+					Message:  fmt.Sprintf("path segment '%s' should be lowercase and hyphen-separated", segment),
+				})
+			}
+		}
+
+		methods := api.Paths[path]
+		sortedMethods := make([]string, 0, len(methods))
+		for method := range methods {
+			sortedMethods = append(sortedMethods, method)
+		}
+		sort.Strings(sortedMethods)
+
+		for _, method := range sortedMethods {
+			operation := methods[method]
+			location := strings.ToUpper(method) + " " + path
+
+			if operation.Summary == "" {
+				findings = append(findings, LintFinding{
+					Severity: "warning",
+					Location: location,
+					Message:  "operation has no summary",
+				})
+			}
+
+			var hasClientError bool
+			for code := range operation.Responses {
+				if strings.HasPrefix(code, "4") {
+					hasClientError = true
+				}
+			}
+			if !hasClientError {
+				findings = append(findings, LintFinding{
+					Severity: "warning",
+					Location: location,
+					Message:  "operation has no 4xx response",
+				})
+			}
+		}
+	}
+
+	for _, plural := range sortedKeys(segmentPaths) {
+		var singulars []string
+		switch {
+		case strings.HasSuffix(plural, "ies"):
+			singulars = []string{strings.TrimSuffix(plural, "ies") + "y"}
+		case strings.HasSuffix(plural, "es"):
+			singulars = []string{strings.TrimSuffix(plural, "es"), strings.TrimSuffix(plural, "s")}
+		case strings.HasSuffix(plural, "s"):
+			singulars = []string{strings.TrimSuffix(plural, "s")}
+		}
+
+		for _, singular := range singulars {
+			if path, ok := segmentPaths[singular]; ok && singular != "" {
+				findings = append(findings, LintFinding{
+					Severity: "info",
+					Location: path,
+					Message:  fmt.Sprintf("path segment '%s' is the singular form of '%s' used in %s; use one form consistently", singular, plural, segmentPaths[plural]),
+				})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+func specStats(api OpenAPI) Stats {
+	stats := Stats{
+		Paths:      len(api.Paths),
+		Operations: make(map[string]int),
+		Responses:  make(map[string]int),
+		Schemas:    len(api.Components.Schemas),
+	}
+
+	for _, methods := range api.Paths {
+		for method, operation := range methods {
+			stats.Operations[strings.ToUpper(method)]++
+			stats.Parameters += len(operation.Parameters)
+
+			for code := range operation.Responses {
+				class := code
+				if len(code) == 3 {
+					class = code[:1] + "xx"
+				}
+				stats.Responses[class]++
+			}
+		}
+	}
+
+	return stats
+}
+
+func formatCounts(counts map[string]int) string {
+	var total int
+	keys := make([]string, 0, len(counts))
+	for key, count := range counts {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s %d", key, counts[key]))
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprint(total)
+	}
+
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
+func printCounts(w io.Writer, stats Stats) {
+	var operations int
+	for _, count := range stats.Operations {
+		operations += count
+	}
+	fmt.Fprintf(w, "paths=%d operations=%d\n", stats.Paths, operations)
+}
+
+func printStats(w io.Writer, stats Stats) {
+	fmt.Fprintf(w, "Paths: %d\n", stats.Paths)
+	fmt.Fprintf(w, "Operations: %s\n", formatCounts(stats.Operations))
+	fmt.Fprintf(w, "Parameters: %d\n", stats.Parameters)
+	fmt.Fprintf(w, "Responses: %s\n", formatCounts(stats.Responses))
+	fmt.Fprintf(w, "Schemas: %d\n", stats.Schemas)
+}
+
+func omitProperties(schema Schema, omit func(Property) bool) Schema {
+	properties := make(map[string]Property, len(schema.Properties))
+	for propName, prop := range schema.Properties {
+		if !omit(prop) {
+			properties[propName] = prop
+		}
+	}
+	schema.Properties = properties
+
+	return schema
+}
+
+func formatValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		jsonBytes, _ := json.Marshal(value)
 		return string(jsonBytes)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func formatName(name string) string {
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "`" + name + "`"
+		}
+	}
+
+	return name
+}
+
+func formatProperties(schema Schema, indent string) string {
+	var sb strings.Builder
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, propName := range schema.Required {
+		required[propName] = true
+	}
+
+	for _, propName := range sortedKeys(schema.Properties) {
+		prop := schema.Properties[propName]
+		var example string
+		if prop.Example != nil {
+			example = ": " + formatValue(prop.Example)
+		}
+		propType := prop.Type
+		if prop.Format == "binary" || prop.Format == "byte" {
+			propType += ", " + prop.Format
+		}
+		if prop.Nullable {
+			sb.WriteString(indent + "+ " + formatName(propName) + example + " (" + propType + ", " + isRequired(required[propName]) + ", nullable)\n")
+		} else {
+			sb.WriteString(indent + "+ " + formatName(propName) + example + " (" + propType + ", " + isRequired(required[propName]) + ")\n")
+		}
+		if prop.Default != nil {
+			sb.WriteString(indent + "    + Default: " + formatValue(prop.Default) + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+func formatAttributes(schemaType string, schema Schema) string {
+	if schemaType == "array" {
+		return "+ Attributes (array)\n    + (object)\n" + formatProperties(schema, "        ")
+	}
+
+	return "+ Attributes \n" + formatProperties(schema, "    ")
+}
+
+func formatBody(schemaType string, schema Schema) string {
+	if schemaType != "object" && schemaType != "array" {
+		return ""
+	}
+
+	item := make(map[string]interface{})
+	for propName, prop := range schema.Properties {
+		propValue := exampleValue(prop)
+
+		if prop.Nullable {
+			item[propName] = nil
+		} else {
+			item[propName] = propValue
+		}
+	}
+
+	var example interface{} = item
+	if schemaType == "array" {
+		example = []interface{}{item}
+	}
+
+	jsonBytes, _ := json.MarshalIndent(example, "        ", "    ")
+	return string(jsonBytes)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	return ""
+	sort.Strings(keys)
+
+	return keys
 }
 
 func isRequired(required bool) string {
@@ -200,11 +958,26 @@ func isRequired(required bool) string {
 	return "optional"
 }
 
+func exampleValue(prop Property) interface{} {
+	switch {
+	case prop.Example != nil:
+		return prop.Example
+	case prop.Const != nil:
+		return prop.Const
+	case prop.Default != nil:
+		return prop.Default
+	case len(prop.Enum) > 0:
+		return prop.Enum[0]
+	}
+
+	return propValue(prop.Type)
+}
+
 func propValue(valueType string) interface{} {
 	switch valueType {
 	case "string":
 		return "string"
-	case "number":
+	case "number", "integer":
 		return 0
 	case "boolean":
 		return true
@@ -215,12 +988,119 @@ func propValue(valueType string) interface{} {
 	}
 }
 
-func formatOperation(method, path string, operation Method, componentSchemas map[string]Schema) string {
+func humanizeID(id string) string {
+	var words []string
+	var word []rune
+
+	runes := []rune(id)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == '.' || unicode.IsSpace(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextIsLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+
+	return strings.Join(words, " ")
+}
+
+func formatExternalDocs(docs ExternalDocs) string {
+	if docs.Description == "" {
+		return docs.URL
+	}
+
+	return "[" + docs.Description + "](" + docs.URL + ")"
+}
+
+func formatExample(example json.RawMessage, examples map[string]Example, componentExamples map[string]Example, opts ConversionOptions) (string, bool) {
+	if len(example) == 0 && len(examples) > 0 {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		namedExample := examples[names[0]]
+		if namedExample.Ref != "" {
+			namedExample = componentExamples[strings.TrimPrefix(namedExample.Ref, "#/components/examples/")]
+		}
+		example = namedExample.Value
+	}
+
+	if len(example) == 0 || string(example) == "null" {
+		return "", false
+	}
+
+	if opts.PreserveExampleOrder {
+		var buf bytes.Buffer
+		err := json.Indent(&buf, example, "        ", "    ")
+		if err != nil {
+			return "", false
+		}
+		return buf.String(), true
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(example))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err != nil {
+		return "", false
+	}
+
+	jsonBytes, _ := json.MarshalIndent(value, "        ", "    ")
+	return string(jsonBytes), true
+}
+
+func formatOperation(method, path string, operation Method, componentSchemas map[string]Schema, componentExamples map[string]Example, opts ConversionOptions) string {
 	var sb strings.Builder
 
-	sb.WriteString("## " + operation.Summary + " [" + strings.ToUpper(method) + " " + path + "]\n")
-	if operation.Description != nil {
-		sb.WriteString(*operation.Description + "\n")
+	name := operation.Summary
+	if name == "" && opts.OperationIDNames {
+		name = humanizeID(operation.OperationID)
+	}
+
+	description := operation.Description
+	if runes := []rune(name); opts.MaxHeadingLength > 0 && len(runes) > opts.MaxHeadingLength {
+		fullName := name
+		if description != nil {
+			fullName += "\n\n" + *description
+		}
+		description = &fullName
+		name = strings.TrimSpace(string(runes[:opts.MaxHeadingLength-1])) + "…"
+	}
+
+	sb.WriteString("## " + name + " [" + strings.ToUpper(method) + " " + path + "]\n")
+	if description != nil {
+		sb.WriteString(*description + "\n")
+	}
+	if operation.ExternalDocs != nil && operation.ExternalDocs.URL != "" {
+		if description != nil {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("See: " + formatExternalDocs(*operation.ExternalDocs) + "\n")
 	}
 	sb.WriteString("\n")
 
@@ -235,8 +1115,43 @@ func formatOperation(method, path string, operation Method, componentSchemas map
 		sb.WriteString("+ Parameters\n")
 	}
 	for _, param := range operation.Parameters {
-		if param.In == "query" {
-			sb.WriteString("    + " + param.Name + " (" + param.Schema.Type + ", " + isRequired(param.Required) + ") \n")
+		if param.In != "query" {
+			continue
+		}
+
+		paramType := param.Schema.Type
+		var notes []string
+		if param.Deprecated {
+			notes = append(notes, "Deprecated")
+		}
+		if len(param.Content) > 0 {
+			mediaTypes := make([]string, 0, len(param.Content))
+			for mediaType := range param.Content {
+				mediaTypes = append(mediaTypes, mediaType)
+			}
+			sort.Strings(mediaTypes)
+
+			if paramType == "" {
+				paramType = param.Content[mediaTypes[0]].Schema.Type
+			}
+			notes = append(notes, "Serialized as "+mediaTypes[0])
+		}
+
+		var description string
+		if len(notes) > 0 {
+			description = " - " + strings.Join(notes, ". ")
+		}
+
+		if len(param.Schema.Enum) > 0 {
+			paramType = "enum[" + paramType + "]"
+		}
+
+		sb.WriteString("    + " + formatName(param.Name) + " (" + paramType + ", " + isRequired(param.Required) + ")" + description + " \n")
+		if len(param.Schema.Enum) > 0 {
+			sb.WriteString("        + Members\n")
+			for _, value := range param.Schema.Enum {
+				sb.WriteString("            + `" + formatValue(value) + "`\n")
+			}
 		}
 	}
 	if hasQuery {
@@ -258,19 +1173,36 @@ func formatOperation(method, path string, operation Method, componentSchemas map
 		}
 
 		ref := strings.TrimPrefix(refPath, "#/components/schemas/")
-		attributesSchema := componentSchemas[ref]
+		attributesSchema := omitProperties(componentSchemas[ref], func(prop Property) bool {
+			return prop.ReadOnly
+		})
 
-		sb.WriteString(formatAttributes(attributesSchema))
-		sb.WriteString("\n")
+		requestBodyContent := operation.RequestBody.Content.ApplicationJSON
+		requestBody, ok := formatExample(requestBodyContent.Example, requestBodyContent.Examples, componentExamples, opts)
+		if !ok {
+			requestBody = formatBody(schemaType, attributesSchema)
+		}
+
+		hasAttributes := len(attributesSchema.Properties) > 0
+		if !opts.OmitEmpty || hasAttributes || requestBody != "" {
+			if !opts.OmitEmpty || hasAttributes {
+				sb.WriteString(formatAttributes(schemaType, attributesSchema))
+				sb.WriteString("\n")
+			}
+
+			sb.WriteString("+ Request (application/json)\n\n")
+			sb.WriteString("  + Headers\n\n")
+			sb.WriteString("    Authorization: Bearer <JWT>\n\n")
 
-		sb.WriteString("+ Request (application/json)\n\n")
-		sb.WriteString("  + Headers\n\n")
-		sb.WriteString("    Authorization: Bearer <JWT>\n\n")
-		sb.WriteString("  + Body\n\n")
-		sb.WriteString("        " + formatBody(schemaType, attributesSchema) + "\n\n")
+			if !opts.OmitEmpty || requestBody != "" {
+				sb.WriteString("  + Body\n\n")
+				sb.WriteString("        " + requestBody + "\n\n")
+			}
+		}
 	}
 
-	for code, response := range operation.Responses {
+	for _, code := range sortedKeys(operation.Responses) {
+		response := operation.Responses[code]
 		var refPath, schemaType string
 		responseBodySchema := response.Content.ApplicationJSON.Schema
 		if responseBodySchema.Ref != "" {
@@ -283,12 +1215,32 @@ func formatOperation(method, path string, operation Method, componentSchemas map
 			refPath = responseBodySchema.Items.Ref
 		}
 
+		responseBody, hasExample := formatExample(response.Content.ApplicationJSON.Example, response.Content.ApplicationJSON.Examples, componentExamples, opts)
+		if !opts.KeepEmptyResponses && refPath == "" && !hasExample && response.Description == "" {
+			continue
+		}
+
 		ref := strings.TrimPrefix(refPath, "#/components/schemas/")
 		sb.WriteString("+ Response " + code + " (application/json)\n")
-		responseSchema := componentSchemas[ref]
+		responseSchema := omitProperties(componentSchemas[ref], func(prop Property) bool {
+			return prop.WriteOnly
+		})
+		if !hasExample {
+			responseBody = formatBody(schemaType, responseSchema)
+		}
 
-		sb.WriteString("  + Body\n\n")
-		sb.WriteString("        " + formatBody(schemaType, responseSchema) + "\n\n")
+		if _, ok := componentSchemas[ref]; ok {
+			if schemaType == "array" {
+				sb.WriteString("  + Attributes (array[" + ref + "])\n")
+			} else {
+				sb.WriteString("  + Attributes (" + ref + ")\n")
+			}
+		}
+
+		if !opts.OmitEmpty || responseBody != "" {
+			sb.WriteString("  + Body\n\n")
+			sb.WriteString("        " + responseBody + "\n\n")
+		}
 	}
 
 	sb.WriteString("\n")
@@ -296,13 +1248,48 @@ func formatOperation(method, path string, operation Method, componentSchemas map
 	return sb.String()
 }
 
-func createAPIBlueprint(api OpenAPI) (string, error) {
+func formatGroupDescription(name string, tags []Tag) string {
+	for _, tag := range tags {
+		hasExternalDocs := tag.ExternalDocs != nil && tag.ExternalDocs.URL != ""
+		if tag.Name != name || (tag.Description == "" && !hasExternalDocs) {
+			continue
+		}
+
+		var sb strings.Builder
+		if tag.Description != "" {
+			sb.WriteString("\n" + strings.TrimRight(tag.Description, "\n") + "\n")
+		}
+		if hasExternalDocs {
+			sb.WriteString("\nSee: " + formatExternalDocs(*tag.ExternalDocs) + "\n")
+		}
+		sb.WriteString("\n")
+
+		return sb.String()
+	}
+
+	return "\nResources related to " + name + "\n\n"
+}
+
+func createAPIBlueprint(api OpenAPI, opts ConversionOptions) (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("FORMAT: 1A\n")
-	sb.WriteString("HOST: http://api.example.com\n\n")
+	if len(api.Servers) == 0 {
+		sb.WriteString("HOST: http://api.example.com\n")
+	}
+	for _, server := range api.Servers {
+		sb.WriteString("HOST: " + server.URL)
+		if server.Description != "" {
+			sb.WriteString(" - " + server.Description)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
 
 	sb.WriteString("# " + api.Info.Title + "\n\n")
+	if api.Info.Description != "" {
+		sb.WriteString(strings.TrimRight(api.Info.Description, "\n") + "\n\n")
+	}
 
 	sortedPaths := make([]string, 0, len(api.Paths))
 	for path := range api.Paths {
@@ -314,16 +1301,55 @@ func createAPIBlueprint(api OpenAPI) (string, error) {
 	for _, path := range sortedPaths {
 
 		methods := api.Paths[path]
-		for method, operation := range methods {
-			if len(operation.Tags) > 0 {
+		for _, method := range sortedKeys(methods) {
+			operation := methods[method]
+			if opts.EmitGroups && len(operation.Tags) > 0 {
 				if operation.Tags[0] != currentGroup {
 					sb.WriteString("# Group " + operation.Tags[0] + "\n")
-					sb.WriteString("\nResources related to " + operation.Tags[0] + "\n\n")
+					sb.WriteString(formatGroupDescription(operation.Tags[0], api.Tags))
 				}
 
 				currentGroup = operation.Tags[0]
 			}
-			sb.WriteString(formatOperation(method, path, operation, api.Components.Schemas))
+			sb.WriteString(formatOperation(method, path, operation, api.Components.Schemas, api.Components.Examples, opts))
+		}
+	}
+
+	dataStructures := make(map[string]bool)
+	for _, methods := range api.Paths {
+		for _, operation := range methods {
+			for _, response := range operation.Responses {
+				responseBodySchema := response.Content.ApplicationJSON.Schema
+				for _, refPath := range []string{responseBodySchema.Ref, responseBodySchema.Items.Ref} {
+					ref := strings.TrimPrefix(refPath, "#/components/schemas/")
+					if _, ok := api.Components.Schemas[ref]; ok {
+						dataStructures[ref] = true
+					}
+				}
+			}
+		}
+	}
+
+	if len(dataStructures) > 0 {
+		names := make([]string, 0, len(dataStructures))
+		for name := range dataStructures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		sb.WriteString("# Data Structures\n\n")
+		for _, name := range names {
+			schemaType := api.Components.Schemas[name].Type
+			if schemaType == "" {
+				schemaType = "object"
+			}
+
+			sb.WriteString("## " + name + " (" + schemaType + ")\n")
+			responseSchema := omitProperties(api.Components.Schemas[name], func(prop Property) bool {
+				return prop.WriteOnly
+			})
+			sb.WriteString(formatProperties(responseSchema, ""))
+			sb.WriteString("\n")
 		}
 	}
 
@@ -0,0 +1,982 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePathsTrailingSlash(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List Users"}},
+			"/users/": {"get": {"summary": "Duplicate"}, "post": {"summary": "Create User"}},
+			"//": {"get": {"summary": "Root"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	api, warnings := normalizePaths(api)
+
+	if len(api.Paths) != 2 {
+		t.Fatalf("expected 2 paths, got %d: %v", len(api.Paths), api.Paths)
+	}
+	if got := api.Paths["/users"]["get"].Summary; got != "List Users" {
+		t.Errorf("expected the first GET /users to be kept, got %q", got)
+	}
+	if got := api.Paths["/users"]["post"].Summary; got != "Create User" {
+		t.Errorf("expected POST /users/ to be merged into /users, got %q", got)
+	}
+	if got := api.Paths["/"]["get"].Summary; got != "Root" {
+		t.Errorf("expected // to normalize to /, got %q", got)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected 1 warning for the dropped duplicate, got %v", warnings)
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	var out bytes.Buffer
+	err := runSelfTest(&out)
+	if err != nil {
+		t.Fatalf("runSelfTest: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "PASS") {
+		t.Errorf("expected a PASS line, got %q", out.String())
+	}
+}
+
+func TestHumanizeID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"listUsers", "List Users"},
+		{"getHTTPResponse", "Get HTTP Response"},
+		{"user_id", "User Id"},
+		{"delete-user", "Delete User"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeID(tt.id); got != tt.want {
+			t.Errorf("humanizeID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOpenAPIDuplicateOperationID(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"operationId": "listUsers"}},
+			"/people": {"get": {"operationId": "listUsers"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	err = validateOpenAPI(api)
+	if err == nil {
+		t.Fatal("expected an error for a duplicate operationId")
+	}
+	if !strings.Contains(err.Error(), "GET /people") || !strings.Contains(err.Error(), "GET /users") {
+		t.Errorf("expected both locations in the error, got %q", err)
+	}
+}
+
+func TestResolveExternalRefs(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "schemas"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "schemas", "User.json"), []byte(`{"type": "object", "properties": {"id": {"type": "integer"}}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "./schemas/User.json"}}}}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	err = resolveExternalRefs(&api, dir)
+	if err != nil {
+		t.Fatalf("resolveExternalRefs: %v", err)
+	}
+
+	if _, ok := api.Components.Schemas["User"].Properties["id"]; !ok {
+		t.Errorf("expected User to be inlined into components, got %v", api.Components.Schemas)
+	}
+	if got := api.Paths["/users"]["get"].Responses["200"].Content.ApplicationJSON.Schema.Ref; got != "#/components/schemas/User" {
+		t.Errorf("expected the reference to be rewritten, got %q", got)
+	}
+}
+
+func TestResolveExternalRefsRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "spec")
+	err := os.MkdirAll(base, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "Secret.json"), []byte(`{"type": "object"}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "../Secret.json"}}}}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	err = resolveExternalRefs(&api, base)
+	if err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected a path traversal error, got %v", err)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/users", "/users", true},
+		{"/users", "/users/{id}", false},
+		{"/users*", "/users", true},
+		{"/users*", "/users/{id}", true},
+		{"/orders/{id}", "/orders/{id}", true},
+		{"*/items", "/orders/{id}/items", true},
+		{"*/items", "/orders/{id}", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFilterPaths(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}}}}}},
+			"/orders/{id}": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}}}}},
+			"/invoices": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Invoice"}}}}}}}
+		},
+		"components": {
+			"schemas": {
+				"User": {"type": "object"},
+				"Order": {"type": "object"},
+				"Invoice": {"type": "object"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	api = filterPaths(api, []string{"/users*", " /orders/{id}"})
+
+	if got := strings.Join(sortedKeys(api.Paths), ","); got != "/orders/{id},/users" {
+		t.Errorf("unexpected paths after filtering: %s", got)
+	}
+	if got := strings.Join(sortedKeys(api.Components.Schemas), ","); got != "Order,User" {
+		t.Errorf("unexpected schemas after filtering: %s", got)
+	}
+}
+
+func TestReadOnlyAndWriteOnlyExamples(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {
+				"post": {
+					"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+					"responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"User": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "integer", "readOnly": true},
+						"password": {"type": "string", "writeOnly": true}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("post", "/users", api.Paths["/users"]["post"], api.Components.Schemas, nil, ConversionOptions{})
+	request, response, ok := strings.Cut(output, "+ Response 201")
+	if !ok {
+		t.Fatalf("expected a 201 response in:\n%s", output)
+	}
+
+	if strings.Contains(request, `"id"`) || !strings.Contains(request, `"password"`) {
+		t.Errorf("expected the request to omit id and keep password:\n%s", request)
+	}
+	if !strings.Contains(response, `"id"`) || strings.Contains(response, `"password"`) {
+		t.Errorf("expected the response to keep id and omit password:\n%s", response)
+	}
+}
+
+func TestStripDescriptionsClearsTags(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"tags": [{"name": "Users", "description": "Manage user accounts."}],
+		"paths": {"/users": {"get": {"summary": "List Users", "tags": ["Users"]}}}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	err = applyTransforms(&api, []string{"strip-descriptions"})
+	if err != nil {
+		t.Fatalf("applyTransforms: %v", err)
+	}
+
+	output, err := createAPIBlueprint(api, ConversionOptions{EmitGroups: true})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	if strings.Contains(output, "Manage user accounts.") {
+		t.Errorf("expected the tag description to be stripped:\n%s", output)
+	}
+}
+
+func TestSetServersSkipsEmptyEntries(t *testing.T) {
+	var api OpenAPI
+	setServers(&api, strings.Split("https://a.example.com,, https://b.example.com,", ","))
+
+	if len(api.Servers) != 2 || api.Servers[0].URL != "https://a.example.com" || api.Servers[1].URL != "https://b.example.com" {
+		t.Errorf("unexpected servers: %+v", api.Servers)
+	}
+}
+
+func TestLintOpenAPIPluralSingular(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List Users", "responses": {"404": {}}}},
+			"/user/{id}": {"get": {"summary": "Get User", "responses": {"404": {}}}},
+			"/categories": {"get": {"summary": "List Categories", "responses": {"404": {}}}},
+			"/category/{id}": {"get": {"summary": "Get Category", "responses": {"404": {}}}},
+			"/status": {"get": {"summary": "Status", "responses": {"404": {}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	findings := lintOpenAPI(api)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Location != "/category/{id}" || findings[1].Location != "/user/{id}" {
+		t.Errorf("unexpected finding locations: %+v", findings)
+	}
+}
+
+func TestNumericExamplesKeepTheirForm(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"components": {
+			"schemas": {
+				"Counter": {
+					"type": "object",
+					"properties": {
+						"count": {"type": "integer", "example": 42},
+						"ratio": {"type": "number", "example": 1.5}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+	schema := api.Components.Schemas["Counter"]
+
+	body := formatBody("object", schema)
+	if !strings.Contains(body, `"count": 42,`) || !strings.Contains(body, `"ratio": 1.5`) {
+		t.Errorf("expected 42 and 1.5 unchanged in the body:\n%s", body)
+	}
+
+	attributes := formatProperties(schema, "")
+	if !strings.Contains(attributes, "+ count: 42 (integer, optional)") || !strings.Contains(attributes, "+ ratio: 1.5 (number, optional)") {
+		t.Errorf("expected 42 and 1.5 unchanged in the attributes:\n%s", attributes)
+	}
+}
+
+func TestSharedPathItem(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/a": {"$ref": "#/components/pathItems/Shared"},
+			"/b": {"$ref": "#/components/pathItems/Shared", "post": {"operationId": "createB", "responses": {"201": {"description": "Created"}}}}
+		},
+		"components": {
+			"pathItems": {
+				"Shared": {"get": {"operationId": "getShared", "responses": {"200": {"description": "OK"}}}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	if got := strings.Join(sortedKeys(api.Paths["/a"]), ","); got != "get" {
+		t.Errorf("unexpected operations for /a: %s", got)
+	}
+	if got := strings.Join(sortedKeys(api.Paths["/b"]), ","); got != "get,post" {
+		t.Errorf("unexpected operations for /b: %s", got)
+	}
+
+	operation := api.Paths["/a"]["get"]
+	operation.Summary = "changed"
+	api.Paths["/a"]["get"] = operation
+	delete(api.Paths["/a"]["get"].Responses, "200")
+	if api.Paths["/b"]["get"].Summary != "" || len(api.Paths["/b"]["get"].Responses) != 1 {
+		t.Errorf("changes to /a leaked into /b")
+	}
+
+	_, err = parseOpenAPI([]byte(`{
+		"paths": {
+			"/a": {"$ref": "#/components/pathItems/Shared", "get": {"responses": {"200": {"description": "OK"}}}}
+		},
+		"components": {
+			"pathItems": {
+				"Shared": {"get": {"responses": {"200": {"description": "OK"}}}}
+			}
+		}
+	}`))
+	if err == nil || !strings.Contains(err.Error(), "conflicts with path item") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestKeepEmptyResponses(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users/{id}": {"delete": {"summary": "Delete User", "responses": {"204": {}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+	operation := api.Paths["/users/{id}"]["delete"]
+
+	kept := formatOperation("delete", "/users/{id}", operation, nil, nil, ConversionOptions{KeepEmptyResponses: true})
+	if !strings.Contains(kept, "+ Response 204") {
+		t.Errorf("expected empty response to be kept:\n%s", kept)
+	}
+
+	dropped := formatOperation("delete", "/users/{id}", operation, nil, nil, ConversionOptions{})
+	if strings.Contains(dropped, "+ Response 204") {
+		t.Errorf("expected empty response to be dropped:\n%s", dropped)
+	}
+}
+
+func TestWriteOutputDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.apib")
+
+	var out bytes.Buffer
+	if err := writeOutput(&out, path, "FORMAT: 1A\n", true); err != nil {
+		t.Fatalf("writeOutput: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no output file in dry run, stat returned %v", err)
+	}
+	if !strings.Contains(out.String(), "Dry run: 11 bytes") {
+		t.Errorf("unexpected dry run message: %s", out.String())
+	}
+
+	if err := writeOutput(&out, path, "FORMAT: 1A\n", false); err != nil {
+		t.Fatalf("writeOutput: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "FORMAT: 1A\n" {
+		t.Errorf("unexpected output file: %q, %v", data, err)
+	}
+}
+
+func TestSpecStats(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {
+				"get": {"parameters": [{"name": "page", "in": "query"}, {"name": "limit", "in": "query"}], "responses": {"200": {}, "default": {}}},
+				"post": {"responses": {"201": {}, "400": {}}}
+			},
+			"/users/{id}": {
+				"get": {"parameters": [{"name": "id", "in": "path"}], "responses": {"200": {}, "404": {}}}
+			}
+		},
+		"components": {
+			"schemas": {"User": {"type": "object"}, "Error": {"type": "object"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	stats := specStats(api)
+
+	if stats.Paths != 2 || stats.Parameters != 3 || stats.Schemas != 2 {
+		t.Errorf("unexpected counts: paths=%d parameters=%d schemas=%d", stats.Paths, stats.Parameters, stats.Schemas)
+	}
+	if stats.Operations["GET"] != 2 || stats.Operations["POST"] != 1 {
+		t.Errorf("unexpected operations: %v", stats.Operations)
+	}
+	if stats.Responses["2xx"] != 3 || stats.Responses["4xx"] != 2 || stats.Responses["default"] != 1 {
+		t.Errorf("unexpected responses: %v", stats.Responses)
+	}
+}
+
+func TestOmitEmptyRequest(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users/{id}/refresh": {"post": {"summary": "Refresh User", "responses": {"204": {"description": "Refreshed"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+	operation := api.Paths["/users/{id}/refresh"]["post"]
+
+	omitted := formatOperation("post", "/users/{id}/refresh", operation, nil, nil, ConversionOptions{OmitEmpty: true})
+	if strings.Contains(omitted, "+ Request") || strings.Contains(omitted, "+ Attributes") || strings.Contains(omitted, "Authorization") {
+		t.Errorf("expected empty request section to be omitted:\n%s", omitted)
+	}
+	if !strings.Contains(omitted, "+ Response 204") {
+		t.Errorf("expected response to be kept:\n%s", omitted)
+	}
+
+	kept := formatOperation("post", "/users/{id}/refresh", operation, nil, nil, ConversionOptions{})
+	if !strings.Contains(kept, "+ Request (application/json)") {
+		t.Errorf("expected request section without -omit-empty:\n%s", kept)
+	}
+}
+
+func TestApplyTransforms(t *testing.T) {
+	registerTransform("test-rename", func(api *OpenAPI) error {
+		api.Info.Title = "Renamed"
+		return nil
+	})
+	defer delete(transforms, "test-rename")
+
+	var api OpenAPI
+	if err := applyTransforms(&api, strings.Split("test-rename,", ",")); err != nil {
+		t.Fatalf("applyTransforms: %v", err)
+	}
+	if api.Info.Title != "Renamed" {
+		t.Errorf("expected registered transform to run, got title %q", api.Info.Title)
+	}
+
+	err := applyTransforms(&api, []string{"missing"})
+	if err == nil || !strings.Contains(err.Error(), "unknown transform 'missing'") {
+		t.Errorf("expected unknown transform error, got %v", err)
+	}
+}
+
+func TestHostLines(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"info": {"title": "Users"},
+		"servers": [
+			{"url": "https://api.example.com", "description": "Production"},
+			{"url": "https://staging.example.com", "description": "Staging"}
+		],
+		"paths": {}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	apib, err := createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	for _, host := range []string{"HOST: https://api.example.com - Production\n", "HOST: https://staging.example.com - Staging\n"} {
+		if !strings.Contains(apib, host) {
+			t.Errorf("expected %q in:\n%s", host, apib)
+		}
+	}
+	if strings.Contains(apib, "http://api.example.com") {
+		t.Errorf("unexpected fallback host in:\n%s", apib)
+	}
+
+	api.Servers = nil
+	apib, err = createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	if !strings.Contains(apib, "HOST: http://api.example.com\n") {
+		t.Errorf("expected fallback host in:\n%s", apib)
+	}
+}
+
+func TestDataStructures(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users/{id}": {"get": {"summary": "Get User", "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}}}},
+			"/tags": {"get": {"summary": "List Tags", "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Tags"}}}}}}}
+		},
+		"components": {
+			"schemas": {
+				"User": {"properties": {"name": {"type": "string"}}},
+				"Tags": {"type": "array"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	apib, err := createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	for _, want := range []string{"+ Attributes (User)\n", "## User (object)\n", "## Tags (array)\n"} {
+		if !strings.Contains(apib, want) {
+			t.Errorf("expected %q in:\n%s", want, apib)
+		}
+	}
+}
+
+func TestEmitGroups(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List Users", "tags": ["Users"], "responses": {"200": {"description": "OK"}}}},
+			"/orders": {"get": {"summary": "List Orders", "tags": ["Orders"], "responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	apib, err := createAPIBlueprint(api, ConversionOptions{EmitGroups: true})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	if !strings.Contains(apib, "# Group Users\n") || !strings.Contains(apib, "# Group Orders\n") {
+		t.Errorf("expected groups in:\n%s", apib)
+	}
+
+	apib, err = createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	if strings.Contains(apib, "# Group") {
+		t.Errorf("unexpected groups in:\n%s", apib)
+	}
+	if !strings.Contains(apib, "## List Users [GET /users]") {
+		t.Errorf("expected operations without groups in:\n%s", apib)
+	}
+}
+
+func TestEnumQueryParameters(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/orders": {"get": {"summary": "List Orders", "parameters": [
+				{"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open", "closed"]}},
+				{"name": "priority", "in": "query", "required": true, "schema": {"type": "integer", "enum": [1, 2]}}
+			], "responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("get", "/orders", api.Paths["/orders"]["get"], nil, nil, ConversionOptions{})
+
+	for _, want := range []string{
+		"+ status (enum[string], optional)",
+		"+ priority (enum[integer], required)",
+		"            + `open`\n            + `closed`\n",
+		"            + `1`\n            + `2`\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestRequiredProperties(t *testing.T) {
+	schema := Schema{
+		Type: "object",
+		Properties: map[string]Property{
+			"name":     {Type: "string"},
+			"email":    {Type: "string"},
+			"nickname": {Type: "string"},
+		},
+		Required: []string{"name", "email"},
+	}
+
+	output := formatProperties(schema, "")
+
+	for _, want := range []string{
+		"+ name (string, required)\n",
+		"+ email (string, required)\n",
+		"+ nickname (string, optional)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestSharedComponentExample(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users/{id}": {"get": {"summary": "Get User", "responses": {
+				"400": {"description": "Bad Request", "content": {"application/json": {"examples": {"error": {"$ref": "#/components/examples/Error"}}}}},
+				"404": {"description": "Not Found", "content": {"application/json": {"examples": {"error": {"$ref": "#/components/examples/Error"}}}}}
+			}}}
+		},
+		"components": {
+			"examples": {
+				"Error": {"summary": "Error", "value": {"message": "failed"}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("get", "/users/{id}", api.Paths["/users/{id}"]["get"], nil, api.Components.Examples, ConversionOptions{})
+
+	if got := strings.Count(output, `"message": "failed"`); got != 2 {
+		t.Errorf("expected the shared example in both responses, found %d:\n%s", got, output)
+	}
+}
+
+func TestPreserveExampleOrder(t *testing.T) {
+	example := json.RawMessage(`{"z":1,"a":2}`)
+
+	preserved, ok := formatExample(example, nil, nil, ConversionOptions{PreserveExampleOrder: true})
+	if !ok || strings.Index(preserved, `"z"`) > strings.Index(preserved, `"a"`) {
+		t.Errorf("expected key order to be preserved:\n%s", preserved)
+	}
+
+	sorted, ok := formatExample(example, nil, nil, ConversionOptions{})
+	if !ok || strings.Index(sorted, `"a"`) > strings.Index(sorted, `"z"`) {
+		t.Errorf("expected keys to be sorted:\n%s", sorted)
+	}
+}
+
+func TestSynthesizedExample(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"components": {
+			"schemas": {
+				"Order": {"type": "object", "properties": {
+					"id": {"type": "integer"},
+					"kind": {"type": "string", "const": "order"},
+					"currency": {"type": "string", "default": "EUR"},
+					"status": {"type": "string", "enum": ["open", "closed"]},
+					"note": {"type": "string"}
+				}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	body := formatBody("object", api.Components.Schemas["Order"])
+
+	var example map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &example); err != nil {
+		t.Fatalf("synthesized example is not valid JSON: %v\n%s", err, body)
+	}
+	want := map[string]interface{}{
+		"id":       float64(0),
+		"kind":     "order",
+		"currency": "EUR",
+		"status":   "open",
+		"note":     "string",
+	}
+	for key, value := range want {
+		if example[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, example[key])
+		}
+	}
+	if len(example) != len(want) {
+		t.Errorf("unexpected example shape: %v", example)
+	}
+}
+
+func TestDefaultDescriptor(t *testing.T) {
+	schema := Schema{
+		Type: "object",
+		Properties: map[string]Property{
+			"name":    {Type: "string", Example: "John", Default: "Anon"},
+			"options": {Type: "object", Default: map[string]interface{}{"theme": "dark"}},
+		},
+	}
+
+	output := formatProperties(schema, "")
+
+	for _, want := range []string{
+		"+ name: John (string, optional)\n    + Default: Anon\n",
+		"+ options (object, optional)\n    + Default: {\"theme\":\"dark\"}\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestContentQueryParameter(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/search": {"get": {"summary": "Search", "parameters": [
+				{"name": "filter", "in": "query", "content": {"application/json": {"schema": {"type": "object"}}}}
+			], "responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("get", "/search", api.Paths["/search"]["get"], nil, nil, ConversionOptions{})
+
+	want := "+ filter (object, optional) - Serialized as application/json"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected %q in:\n%s", want, output)
+	}
+}
+
+func TestOperationExternalDocs(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {
+				"get": {"summary": "List Users", "description": "Returns all users.", "externalDocs": {"url": "https://docs.example.com/users", "description": "User guide"}, "responses": {"200": {"description": "OK"}}},
+				"post": {"summary": "Create User", "externalDocs": {"url": "https://docs.example.com/create"}, "responses": {"201": {"description": "Created"}}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	list := formatOperation("get", "/users", api.Paths["/users"]["get"], nil, nil, ConversionOptions{})
+	want := "Returns all users.\n\nSee: [User guide](https://docs.example.com/users)\n"
+	if !strings.Contains(list, want) {
+		t.Errorf("expected %q in:\n%s", want, list)
+	}
+
+	create := formatOperation("post", "/users", api.Paths["/users"]["post"], nil, nil, ConversionOptions{})
+	want = "## Create User [POST /users]\nSee: https://docs.example.com/create\n"
+	if !strings.Contains(create, want) {
+		t.Errorf("expected %q in:\n%s", want, create)
+	}
+}
+
+func TestFormatNameQuotesSpecialCharacters(t *testing.T) {
+	schema := Schema{
+		Type: "object",
+		Properties: map[string]Property{
+			"content-type": {Type: "string"},
+			"user.name":    {Type: "string"},
+			"user_id":      {Type: "integer"},
+		},
+	}
+
+	output := formatProperties(schema, "")
+
+	for _, want := range []string{
+		"+ `content-type` (string, optional)\n",
+		"+ `user.name` (string, optional)\n",
+		"+ user_id (integer, optional)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestTraceAndConnectOperations(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/debug": {
+				"trace": {"summary": "Trace Request", "responses": {"200": {"description": "OK"}}},
+				"connect": {"summary": "Open Tunnel", "responses": {"200": {"description": "OK"}}},
+				"x-internal": true
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	if got := strings.Join(sortedKeys(api.Paths["/debug"]), ","); got != "connect,trace" {
+		t.Errorf("unexpected operations for /debug: %s", got)
+	}
+
+	apib, err := createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+	for _, want := range []string{"## Trace Request [TRACE /debug]", "## Open Tunnel [CONNECT /debug]"} {
+		if !strings.Contains(apib, want) {
+			t.Errorf("expected %q in:\n%s", want, apib)
+		}
+	}
+}
+
+func TestDeprecatedQueryParameter(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List Users", "parameters": [
+				{"name": "page", "in": "query", "deprecated": true, "schema": {"type": "integer"}},
+				{"name": "cursor", "in": "query", "schema": {"type": "string"}}
+			], "responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("get", "/users", api.Paths["/users"]["get"], nil, nil, ConversionOptions{})
+
+	if !strings.Contains(output, "+ page (integer, optional) - Deprecated") {
+		t.Errorf("expected deprecated page parameter in:\n%s", output)
+	}
+	if strings.Contains(output, "+ cursor (string, optional) - Deprecated") {
+		t.Errorf("unexpected deprecation on cursor parameter in:\n%s", output)
+	}
+}
+
+func TestBinaryFormatAttributes(t *testing.T) {
+	schema := Schema{
+		Type: "object",
+		Properties: map[string]Property{
+			"file":     {Type: "string", Format: "binary"},
+			"checksum": {Type: "string", Format: "byte"},
+			"created":  {Type: "string", Format: "date-time"},
+		},
+		Required: []string{"file"},
+	}
+
+	output := formatAttributes("object", schema)
+
+	for _, want := range []string{
+		"+ file (string, binary, required)\n",
+		"+ checksum (string, byte, optional)\n",
+		"+ created (string, optional)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestMaxHeadingLength(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List every user in the current organization", "description": "Supports paging.", "responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+	operation := api.Paths["/users"]["get"]
+
+	truncated := formatOperation("get", "/users", operation, nil, nil, ConversionOptions{MaxHeadingLength: 15})
+	want := "## List every use… [GET /users]\nList every user in the current organization\n\nSupports paging.\n"
+	if !strings.HasPrefix(truncated, want) {
+		t.Errorf("expected truncated heading %q in:\n%s", want, truncated)
+	}
+
+	full := formatOperation("get", "/users", operation, nil, nil, ConversionOptions{})
+	want = "## List every user in the current organization [GET /users]\nSupports paging.\n"
+	if !strings.HasPrefix(full, want) {
+		t.Errorf("expected full heading %q in:\n%s", want, full)
+	}
+}
+
+func TestInfoDescriptionCodeFence(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"info": {"title": "Users", "description": "Example payload:\n\n` + "```json" + `\n{\n  \"name\": \"John\"\n}\n` + "```" + `\n"},
+		"paths": {}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	apib, err := createAPIBlueprint(api, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("createAPIBlueprint: %v", err)
+	}
+
+	want := "# Users\n\nExample payload:\n\n```json\n{\n  \"name\": \"John\"\n}\n```\n\n"
+	if !strings.Contains(apib, want) {
+		t.Errorf("expected %q in:\n%s", want, apib)
+	}
+}
+
+func TestPrintCounts(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"responses": {"200": {}}}, "post": {"responses": {"201": {}}}},
+			"/users/{id}": {"get": {"responses": {"200": {}}}, "delete": {"responses": {"204": {}}}},
+			"/health": {"head": {"responses": {"200": {}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	var out bytes.Buffer
+	printCounts(&out, specStats(api))
+
+	if got := out.String(); got != "paths=3 operations=5\n" {
+		t.Errorf("unexpected counts: %q", got)
+	}
+}
+
+func TestArrayOfObjectsResponse(t *testing.T) {
+	api, err := parseOpenAPI([]byte(`{
+		"paths": {
+			"/users": {"get": {"summary": "List Users", "responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}}}}}}
+		},
+		"components": {
+			"schemas": {
+				"User": {"type": "object", "properties": {"name": {"type": "string", "example": "John"}}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseOpenAPI: %v", err)
+	}
+
+	output := formatOperation("get", "/users", api.Paths["/users"]["get"], api.Components.Schemas, nil, ConversionOptions{})
+
+	if !strings.Contains(output, "+ Attributes (array[User])\n") {
+		t.Errorf("expected array attributes in:\n%s", output)
+	}
+	_, body, _ := strings.Cut(output, "+ Body\n\n")
+	var example []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &example); err != nil {
+		t.Fatalf("response body is not a JSON array: %v\n%s", err, body)
+	}
+	if len(example) != 1 || example[0]["name"] != "John" {
+		t.Errorf("unexpected array body: %v", example)
+	}
+}